@@ -0,0 +1,35 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+)
+
+func TestFunctionCacheKey_MarksChangeTheKey(t *testing.T) {
+	addr := addrs.NewDefaultProvider("a")
+
+	plain, ok := functionCacheKey(addr, "f", []cty.Value{cty.StringVal("secret")})
+	if !ok {
+		t.Fatal("expected ok=true")
+	}
+
+	marked, ok := functionCacheKey(addr, "f", []cty.Value{cty.StringVal("secret").Mark("sensitive")})
+	if !ok {
+		t.Fatal("expected ok=true for a marked but wholly known argument")
+	}
+
+	if plain == marked {
+		t.Errorf("expected a marked argument to hash differently than the same unmarked value, to avoid a sensitive and a non-sensitive call sharing a cache entry")
+	}
+}
+
+func TestFunctionCacheKey_DoesNotPanicOnMarkedArgument(t *testing.T) {
+	addr := addrs.NewDefaultProvider("a")
+
+	if _, ok := functionCacheKey(addr, "f", []cty.Value{cty.NumberIntVal(5).Mark("sensitive")}); !ok {
+		t.Errorf("expected ok=true for a marked, wholly known argument")
+	}
+}
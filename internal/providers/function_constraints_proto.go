@@ -0,0 +1,101 @@
+package providers
+
+import (
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// FunctionParamConstraintsProto is the wire shape for a
+// FunctionParamConstraints, suitable for embedding in a field added to the
+// plugin6 GetFunctions response so a provider can advertise constraints on
+// its own functions without anything launching an instance of it.
+//
+// This package only owns the Go-side encode/decode pair below; it isn't
+// itself wired into plugin6's generated message types, since those live in
+// a different package. A caller that does have access to that generated
+// code can round-trip a FunctionParamConstraints through this type when
+// filling in or reading that field.
+type FunctionParamConstraintsProto struct {
+	Min           []byte   `json:"min,omitempty"`
+	Max           []byte   `json:"max,omitempty"`
+	AllowedValues [][]byte `json:"allowed_values,omitempty"`
+	Pattern       string   `json:"pattern,omitempty"`
+	NonEmpty      bool     `json:"non_empty,omitempty"`
+}
+
+// EncodeFunctionParamConstraintsProto marshals c to its wire shape. ty is
+// the cty.Type of the FunctionParam that c belongs to, which every
+// cty.Value on c is expected to conform to.
+//
+// AllowedMarks is intentionally not part of the wire shape: marks are a
+// purely in-process concept for deciding whether a value can be checked at
+// all, not a constraint on the value's content, so there's nothing useful
+// for another tool to learn from it without also having cty's mark
+// registry available.
+func EncodeFunctionParamConstraintsProto(c *FunctionParamConstraints, ty cty.Type) (*FunctionParamConstraintsProto, error) {
+	if c == nil {
+		return nil, nil
+	}
+	p := &FunctionParamConstraintsProto{
+		Pattern:  c.Pattern,
+		NonEmpty: c.NonEmpty,
+	}
+	if c.Min != cty.NilVal {
+		raw, err := ctyjson.Marshal(c.Min, ty)
+		if err != nil {
+			return nil, fmt.Errorf("encoding Min constraint: %w", err)
+		}
+		p.Min = raw
+	}
+	if c.Max != cty.NilVal {
+		raw, err := ctyjson.Marshal(c.Max, ty)
+		if err != nil {
+			return nil, fmt.Errorf("encoding Max constraint: %w", err)
+		}
+		p.Max = raw
+	}
+	for _, allowed := range c.AllowedValues {
+		raw, err := ctyjson.Marshal(allowed, ty)
+		if err != nil {
+			return nil, fmt.Errorf("encoding AllowedValues constraint: %w", err)
+		}
+		p.AllowedValues = append(p.AllowedValues, raw)
+	}
+	return p, nil
+}
+
+// DecodeFunctionParamConstraintsProto is the inverse of
+// EncodeFunctionParamConstraintsProto.
+func DecodeFunctionParamConstraintsProto(p *FunctionParamConstraintsProto, ty cty.Type) (*FunctionParamConstraints, error) {
+	if p == nil {
+		return nil, nil
+	}
+	c := &FunctionParamConstraints{
+		Pattern:  p.Pattern,
+		NonEmpty: p.NonEmpty,
+	}
+	if len(p.Min) > 0 {
+		v, err := ctyjson.Unmarshal(p.Min, ty)
+		if err != nil {
+			return nil, fmt.Errorf("decoding Min constraint: %w", err)
+		}
+		c.Min = v
+	}
+	if len(p.Max) > 0 {
+		v, err := ctyjson.Unmarshal(p.Max, ty)
+		if err != nil {
+			return nil, fmt.Errorf("decoding Max constraint: %w", err)
+		}
+		c.Max = v
+	}
+	for _, raw := range p.AllowedValues {
+		v, err := ctyjson.Unmarshal(raw, ty)
+		if err != nil {
+			return nil, fmt.Errorf("decoding AllowedValues constraint: %w", err)
+		}
+		c.AllowedValues = append(c.AllowedValues, v)
+	}
+	return c, nil
+}
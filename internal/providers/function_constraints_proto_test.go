@@ -0,0 +1,58 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestFunctionParamConstraintsProto_RoundTrip(t *testing.T) {
+	c := &FunctionParamConstraints{
+		Min:           cty.NumberIntVal(0),
+		Max:           cty.NumberIntVal(100),
+		AllowedValues: []cty.Value{cty.NumberIntVal(1), cty.NumberIntVal(2)},
+		Pattern:       `^\d+$`,
+		NonEmpty:      true,
+	}
+
+	proto, err := EncodeFunctionParamConstraintsProto(c, cty.Number)
+	if err != nil {
+		t.Fatalf("unexpected error encoding: %s", err)
+	}
+
+	got, err := DecodeFunctionParamConstraintsProto(proto, cty.Number)
+	if err != nil {
+		t.Fatalf("unexpected error decoding: %s", err)
+	}
+
+	if !got.Min.RawEquals(c.Min) {
+		t.Errorf("Min did not round-trip: got %#v, want %#v", got.Min, c.Min)
+	}
+	if !got.Max.RawEquals(c.Max) {
+		t.Errorf("Max did not round-trip: got %#v, want %#v", got.Max, c.Max)
+	}
+	if len(got.AllowedValues) != len(c.AllowedValues) {
+		t.Fatalf("AllowedValues length mismatch: got %d, want %d", len(got.AllowedValues), len(c.AllowedValues))
+	}
+	for i := range c.AllowedValues {
+		if !got.AllowedValues[i].RawEquals(c.AllowedValues[i]) {
+			t.Errorf("AllowedValues[%d] did not round-trip: got %#v, want %#v", i, got.AllowedValues[i], c.AllowedValues[i])
+		}
+	}
+	if got.Pattern != c.Pattern {
+		t.Errorf("Pattern did not round-trip: got %q, want %q", got.Pattern, c.Pattern)
+	}
+	if got.NonEmpty != c.NonEmpty {
+		t.Errorf("NonEmpty did not round-trip: got %v, want %v", got.NonEmpty, c.NonEmpty)
+	}
+}
+
+func TestEncodeFunctionParamConstraintsProto_Nil(t *testing.T) {
+	proto, err := EncodeFunctionParamConstraintsProto(nil, cty.Number)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if proto != nil {
+		t.Errorf("expected a nil proto for nil constraints")
+	}
+}
@@ -0,0 +1,84 @@
+package providers
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// FunctionError wraps the diagnostic that caused a provider-contributed
+// function call to fail, so that callers which only have a plain Go error
+// to work with -- as required by cty's function system -- can still recover
+// the original diagnostic, including any Extra payload it carries (such as
+// a hint that the function requires a configured provider instance), via
+// errors.As.
+type FunctionError struct {
+	// Diagnostic is the diagnostic that best describes the failure. When a
+	// provider's CallFunction response included more than one diagnostic,
+	// this is the first one with error severity; the full set, including
+	// any warnings that accompanied it, is available in Diagnostics.
+	Diagnostic tfdiags.Diagnostic
+
+	// Diagnostics is the full set of diagnostics the provider returned
+	// alongside Diagnostic.
+	Diagnostics tfdiags.Diagnostics
+}
+
+// NewFunctionError builds an error from a set of diagnostics returned by a
+// provider's CallFunction, selecting the diagnostic that best represents
+// the failure. It returns nil if diags has no errors.
+func NewFunctionError(diags tfdiags.Diagnostics) error {
+	if !diags.HasErrors() {
+		return nil
+	}
+	var primary tfdiags.Diagnostic
+	for _, diag := range diags {
+		if diag.Severity() == tfdiags.Error {
+			primary = diag
+			break
+		}
+	}
+	return &FunctionError{
+		Diagnostic:  primary,
+		Diagnostics: diags,
+	}
+}
+
+// Error formats every error-severity diagnostic in e.Diagnostics, not just
+// e.Diagnostic, so that a provider response with more than one error
+// diagnostic doesn't lose everything after the first for a caller that only
+// looks at the plain error string -- matching what
+// tfdiags.Diagnostics.Err() did before CallFunction's response grew a
+// dedicated error type.
+func (e *FunctionError) Error() string {
+	var errs tfdiags.Diagnostics
+	for _, diag := range e.Diagnostics {
+		if diag.Severity() == tfdiags.Error {
+			errs = errs.Append(diag)
+		}
+	}
+
+	switch len(errs) {
+	case 0:
+		return "function call failed"
+	case 1:
+		desc := errs[0].Description()
+		if desc.Detail != "" {
+			return fmt.Sprintf("%s: %s", desc.Summary, desc.Detail)
+		}
+		return desc.Summary
+	default:
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "%d problems:\n", len(errs))
+		for _, diag := range errs {
+			desc := diag.Description()
+			if desc.Detail != "" {
+				fmt.Fprintf(&buf, "\n- %s: %s", desc.Summary, desc.Detail)
+			} else {
+				fmt.Fprintf(&buf, "\n- %s", desc.Summary)
+			}
+		}
+		return buf.String()
+	}
+}
@@ -0,0 +1,55 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+func TestNewFunctionError_NoErrors(t *testing.T) {
+	var diags tfdiags.Diagnostics
+	diags = diags.Append(tfdiags.Sourceless(tfdiags.Warning, "a warning", "not an error"))
+
+	if err := NewFunctionError(diags); err != nil {
+		t.Fatalf("expected nil error for a diagnostics set with no errors, got %s", err)
+	}
+}
+
+func TestFunctionError_Error_Single(t *testing.T) {
+	var diags tfdiags.Diagnostics
+	diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "bad argument", "it was too big"))
+
+	err := NewFunctionError(diags)
+	if err == nil {
+		t.Fatal("expected a non-nil error")
+	}
+
+	got := err.Error()
+	want := "bad argument: it was too big"
+	if got != want {
+		t.Errorf("wrong error message\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestFunctionError_Error_Multiple(t *testing.T) {
+	var diags tfdiags.Diagnostics
+	diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "first problem", "detail one"))
+	diags = diags.Append(tfdiags.Sourceless(tfdiags.Warning, "a warning", "should not appear"))
+	diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "second problem", ""))
+
+	err := NewFunctionError(diags)
+	if err == nil {
+		t.Fatal("expected a non-nil error")
+	}
+
+	got := err.Error()
+	for _, want := range []string{"2 problems:", "first problem: detail one", "second problem"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("error message is missing %q\ngot: %s", want, got)
+		}
+	}
+	if strings.Contains(got, "should not appear") {
+		t.Errorf("error message should not include warning-severity diagnostics\ngot: %s", got)
+	}
+}
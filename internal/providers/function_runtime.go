@@ -0,0 +1,267 @@
+package providers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+)
+
+// DefaultFunctionRuntimeMaxConcurrentPerProvider is the number of provider
+// instances that a FunctionRuntime will launch concurrently for a single
+// provider address. Once that many instances are in use, further borrowers
+// block until one becomes idle again.
+const DefaultFunctionRuntimeMaxConcurrentPerProvider = 8
+
+// DefaultFunctionResultCacheSize is the number of memoized pure-function
+// call results that a FunctionRuntime will retain before evicting the
+// least recently used entry.
+const DefaultFunctionResultCacheSize = 1024
+
+// FunctionRuntime owns the pool of already-launched, unconfigured provider
+// instances and the memoized call results that back calls to
+// provider-contributed functions across an entire Terraform run.
+//
+// Without a FunctionRuntime, FunctionDecl.BuildFunction launches a fresh
+// provider plugin instance for every single call, which is prohibitively
+// expensive for configurations that call the same function many times, for
+// example from inside a for_each. A FunctionRuntime instead keeps idle
+// instances around for reuse and, for functions declared FunctionDecl.Pure,
+// skips the plugin round-trip entirely when a call has already been made
+// with the same arguments.
+//
+// A FunctionRuntime is safe to share between concurrent graph walks. Callers
+// must call Shutdown once they are done with it so that any pooled provider
+// instances are terminated; a FunctionRuntime never closes its pooled
+// instances on its own.
+type FunctionRuntime struct {
+	maxConcurrentPerProvider int
+
+	mu    sync.Mutex
+	pools map[addrs.Provider]*functionProviderPool
+	cache *functionResultCache
+}
+
+// NewFunctionRuntime creates a new, empty FunctionRuntime.
+func NewFunctionRuntime() *FunctionRuntime {
+	return &FunctionRuntime{
+		maxConcurrentPerProvider: DefaultFunctionRuntimeMaxConcurrentPerProvider,
+		pools:                    make(map[addrs.Provider]*functionProviderPool),
+		cache:                    newFunctionResultCache(DefaultFunctionResultCacheSize),
+	}
+}
+
+// Shutdown closes every pooled provider instance. It does not affect
+// in-flight calls, so callers must ensure that all graph evaluation which
+// might call provider functions has already finished.
+func (r *FunctionRuntime) Shutdown() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var firstErr error
+	for _, pool := range r.pools {
+		if err := pool.closeAll(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	r.pools = make(map[addrs.Provider]*functionProviderPool)
+	return firstErr
+}
+
+// borrow retrieves an idle provider instance for addr, launching a new one
+// with factory if none is idle and the per-provider concurrency limit has
+// not yet been reached. The caller must call the returned release func
+// exactly once, whether or not the call made against the instance succeeded.
+func (r *FunctionRuntime) borrow(addr addrs.Provider, factory func() (Interface, error)) (Interface, func(), error) {
+	return r.poolFor(addr).borrow(factory)
+}
+
+func (r *FunctionRuntime) poolFor(addr addrs.Provider) *functionProviderPool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pool, ok := r.pools[addr]
+	if !ok {
+		pool = newFunctionProviderPool(r.maxConcurrentPerProvider)
+		r.pools[addr] = pool
+	}
+	return pool
+}
+
+// functionProviderPool is a pool of already-launched, unconfigured instances
+// of a single provider, bounded by a semaphore so that a burst of parallel
+// function calls can't launch unboundedly many plugin processes.
+type functionProviderPool struct {
+	tokens chan struct{}
+
+	mu   sync.Mutex
+	idle []Interface
+}
+
+func newFunctionProviderPool(maxConcurrent int) *functionProviderPool {
+	tokens := make(chan struct{}, maxConcurrent)
+	for i := 0; i < maxConcurrent; i++ {
+		tokens <- struct{}{}
+	}
+	return &functionProviderPool{tokens: tokens}
+}
+
+func (p *functionProviderPool) borrow(factory func() (Interface, error)) (Interface, func(), error) {
+	<-p.tokens // blocks until a launch slot is available
+
+	p.mu.Lock()
+	var inst Interface
+	if n := len(p.idle); n > 0 {
+		inst = p.idle[n-1]
+		p.idle = p.idle[:n-1]
+	}
+	p.mu.Unlock()
+
+	if inst == nil {
+		var err error
+		inst, err = factory()
+		if err != nil {
+			p.tokens <- struct{}{}
+			return nil, nil, err
+		}
+	}
+
+	var released bool
+	release := func() {
+		if released {
+			return
+		}
+		released = true
+		p.mu.Lock()
+		p.idle = append(p.idle, inst)
+		p.mu.Unlock()
+		p.tokens <- struct{}{}
+	}
+	return inst, release, nil
+}
+
+func (p *functionProviderPool) closeAll() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var firstErr error
+	for _, inst := range p.idle {
+		if err := inst.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	p.idle = nil
+	return firstErr
+}
+
+// functionResultCache is a small LRU cache of memoized results for
+// provider functions that have opted into caching via FunctionDecl.Pure.
+type functionResultCache struct {
+	maxEntries int
+
+	mu    sync.Mutex
+	order []string // least-recently-used first
+	byKey map[string]cty.Value
+}
+
+func newFunctionResultCache(maxEntries int) *functionResultCache {
+	return &functionResultCache{
+		maxEntries: maxEntries,
+		byKey:      make(map[string]cty.Value),
+	}
+}
+
+func (c *functionResultCache) get(key string) (cty.Value, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.byKey[key]
+	if ok {
+		c.touch(key)
+	}
+	return v, ok
+}
+
+func (c *functionResultCache) put(key string, v cty.Value) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.byKey[key]; !exists && len(c.byKey) >= c.maxEntries {
+		c.evictOldest()
+	}
+	c.byKey[key] = v
+	c.touch(key)
+}
+
+func (c *functionResultCache) touch(key string) {
+	for i, k := range c.order {
+		if k == key {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	c.order = append(c.order, key)
+}
+
+func (c *functionResultCache) evictOldest() {
+	if len(c.order) == 0 {
+		return
+	}
+	oldest := c.order[0]
+	c.order = c.order[1:]
+	delete(c.byKey, oldest)
+}
+
+// functionCacheKey derives a stable cache key for a memoized call to a pure
+// provider function, combining the provider address, function name, and a
+// content hash of the arguments. It returns ok=false if any argument is not
+// wholly known, since a call involving unknown values can't be memoized.
+//
+// Each argument is unmarked before hashing, since ctyjson.Marshal can't
+// encode a marked value, and the marks themselves are folded into the hash
+// separately. That keeps, say, a sensitive-marked argument from hashing to
+// the same key as the same underlying value passed unmarked -- which would
+// otherwise let a cache hit for one call hand back a result computed for
+// the other.
+func functionCacheKey(addr addrs.Provider, name string, args []cty.Value) (string, bool) {
+	h := sha256.New()
+	h.Write([]byte(addr.String()))
+	h.Write([]byte{0})
+	h.Write([]byte(name))
+	for _, arg := range args {
+		h.Write([]byte{0})
+		if !arg.IsWhollyKnown() {
+			return "", false
+		}
+		unmarked, marks := arg.UnmarkDeep()
+		raw, err := ctyjson.Marshal(unmarked, unmarked.Type())
+		if err != nil {
+			return "", false
+		}
+		h.Write(raw)
+		h.Write([]byte{0})
+		for _, mark := range sortedMarkStrings(marks) {
+			h.Write([]byte(mark))
+			h.Write([]byte{0})
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// sortedMarkStrings renders a cty.ValueMarks set as a deterministically
+// ordered slice of strings, so that it can be folded into a hash without
+// depending on Go's randomized map iteration order.
+func sortedMarkStrings(marks cty.ValueMarks) []string {
+	ret := make([]string, 0, len(marks))
+	for mark := range marks {
+		ret = append(ret, fmt.Sprintf("%#v", mark))
+	}
+	sort.Strings(ret)
+	return ret
+}
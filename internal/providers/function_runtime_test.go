@@ -0,0 +1,220 @@
+package providers
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+)
+
+// fakeFunctionProvider is a minimal Interface implementation for exercising
+// the pool and cache in this file. It embeds Interface so it satisfies the
+// full method set without having to stub out every unrelated RPC; only
+// Close is expected to be called here.
+type fakeFunctionProvider struct {
+	Interface
+	closed int32
+}
+
+func (p *fakeFunctionProvider) Close() error {
+	atomic.AddInt32(&p.closed, 1)
+	return nil
+}
+
+func TestFunctionProviderPool_BorrowReusesIdleInstance(t *testing.T) {
+	var launches int32
+	factory := func() (Interface, error) {
+		atomic.AddInt32(&launches, 1)
+		return &fakeFunctionProvider{}, nil
+	}
+
+	pool := newFunctionProviderPool(DefaultFunctionRuntimeMaxConcurrentPerProvider)
+
+	inst1, release1, err := pool.borrow(factory)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	release1()
+
+	inst2, release2, err := pool.borrow(factory)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	release2()
+
+	if inst1 != inst2 {
+		t.Errorf("expected the second borrow to reuse the released instance")
+	}
+	if got := atomic.LoadInt32(&launches); got != 1 {
+		t.Errorf("factory called %d times, want 1", got)
+	}
+}
+
+func TestFunctionProviderPool_ConcurrencyLimit(t *testing.T) {
+	factory := func() (Interface, error) {
+		return &fakeFunctionProvider{}, nil
+	}
+
+	pool := newFunctionProviderPool(1)
+
+	_, release, err := pool.borrow(factory)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	borrowed := make(chan struct{})
+	go func() {
+		_, secondRelease, err := pool.borrow(factory)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+			return
+		}
+		secondRelease()
+		close(borrowed)
+	}()
+
+	select {
+	case <-borrowed:
+		t.Fatal("second borrow completed before the first instance was released")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	release()
+
+	select {
+	case <-borrowed:
+	case <-time.After(time.Second):
+		t.Fatal("second borrow never completed after the first instance was released")
+	}
+}
+
+func TestFunctionProviderPool_CloseAll(t *testing.T) {
+	inst := &fakeFunctionProvider{}
+	pool := newFunctionProviderPool(DefaultFunctionRuntimeMaxConcurrentPerProvider)
+
+	_, release, err := pool.borrow(func() (Interface, error) {
+		return inst, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	release()
+
+	if err := pool.closeAll(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if atomic.LoadInt32(&inst.closed) != 1 {
+		t.Errorf("expected the idle instance to be closed exactly once")
+	}
+	if len(pool.idle) != 0 {
+		t.Errorf("expected closeAll to clear the idle list")
+	}
+}
+
+func TestFunctionRuntime_Shutdown(t *testing.T) {
+	r := NewFunctionRuntime()
+	addrA := addrs.NewDefaultProvider("a")
+	addrB := addrs.NewDefaultProvider("b")
+
+	instA := &fakeFunctionProvider{}
+	_, releaseA, err := r.borrow(addrA, func() (Interface, error) { return instA, nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	releaseA()
+
+	instB := &fakeFunctionProvider{}
+	_, releaseB, err := r.borrow(addrB, func() (Interface, error) { return instB, nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	releaseB()
+
+	if err := r.Shutdown(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if atomic.LoadInt32(&instA.closed) != 1 || atomic.LoadInt32(&instB.closed) != 1 {
+		t.Errorf("expected Shutdown to close every pooled instance")
+	}
+	if len(r.pools) != 0 {
+		t.Errorf("expected Shutdown to drop the pools map")
+	}
+}
+
+func TestFunctionResultCache_LRUEviction(t *testing.T) {
+	c := newFunctionResultCache(2)
+
+	c.put("a", cty.StringVal("a"))
+	c.put("b", cty.StringVal("b"))
+
+	// Touch "a" so that "b" becomes the least recently used entry.
+	if _, ok := c.get("a"); !ok {
+		t.Fatal("expected a cache hit for \"a\"")
+	}
+
+	c.put("c", cty.StringVal("c"))
+
+	if _, ok := c.get("b"); ok {
+		t.Errorf("expected \"b\" to have been evicted")
+	}
+	if _, ok := c.get("a"); !ok {
+		t.Errorf("expected \"a\" to survive eviction, since it was touched most recently")
+	}
+	if _, ok := c.get("c"); !ok {
+		t.Errorf("expected \"c\" to be present")
+	}
+}
+
+func TestFunctionResultCache_ConcurrentAccess(t *testing.T) {
+	c := newFunctionResultCache(8)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := string(rune('a' + i%8))
+			c.put(key, cty.NumberIntVal(int64(i)))
+			c.get(key)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestFunctionCacheKey(t *testing.T) {
+	addrA := addrs.NewDefaultProvider("a")
+	addrB := addrs.NewDefaultProvider("b")
+
+	key1, ok := functionCacheKey(addrA, "upper", []cty.Value{cty.StringVal("hello")})
+	if !ok {
+		t.Fatal("expected ok=true for wholly known arguments")
+	}
+
+	key2, ok := functionCacheKey(addrA, "upper", []cty.Value{cty.StringVal("hello")})
+	if !ok {
+		t.Fatal("expected ok=true for wholly known arguments")
+	}
+	if key1 != key2 {
+		t.Errorf("expected the same provider, function, and arguments to hash identically")
+	}
+
+	if key3, ok := functionCacheKey(addrB, "upper", []cty.Value{cty.StringVal("hello")}); !ok || key3 == key1 {
+		t.Errorf("expected a different provider address to change the cache key")
+	}
+
+	if key4, ok := functionCacheKey(addrA, "lower", []cty.Value{cty.StringVal("hello")}); !ok || key4 == key1 {
+		t.Errorf("expected a different function name to change the cache key")
+	}
+
+	if key5, ok := functionCacheKey(addrA, "upper", []cty.Value{cty.StringVal("world")}); !ok || key5 == key1 {
+		t.Errorf("expected different arguments to change the cache key")
+	}
+
+	if _, ok := functionCacheKey(addrA, "upper", []cty.Value{cty.UnknownVal(cty.String)}); ok {
+		t.Errorf("expected ok=false for an unknown argument")
+	}
+}
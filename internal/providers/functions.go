@@ -2,11 +2,15 @@ package providers
 
 import (
 	"fmt"
+	"regexp"
+	"sync"
 
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/function"
 
+	"github.com/hashicorp/terraform/internal/addrs"
 	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/hashicorp/terraform/internal/tfdiags"
 )
 
 type FunctionDecl struct {
@@ -14,6 +18,12 @@ type FunctionDecl struct {
 	VariadicParameter *FunctionParam
 	ReturnType        cty.Type
 
+	// Pure declares that this function always returns the same result for
+	// the same arguments and has no side effects. A FunctionRuntime uses
+	// this to safely memoize call results across the lifetime of a run,
+	// skipping the plugin round-trip entirely on a cache hit.
+	Pure bool
+
 	Description     string
 	DescriptionKind configschema.StringKind
 }
@@ -25,10 +35,176 @@ type FunctionParam struct {
 	Nullable           bool
 	AllowUnknownValues bool
 
+	// Constraints, if set, describes additional restrictions on the values
+	// this parameter will accept, beyond what Type and Nullable already
+	// enforce. BuildFunction checks these before launching the provider, so
+	// a caller passing an out-of-range literal gets a clear error without
+	// paying for a plugin round-trip.
+	Constraints *FunctionParamConstraints
+
+	// Validate, if set, is an additional hook run alongside Constraints for
+	// checks that can't be expressed declaratively, such as cross-field
+	// reasoning. It is called only with a non-null, wholly-known value, and
+	// is also run before the provider is launched. A null or not wholly
+	// known argument skips both Validate and Constraints, even when Nullable
+	// or AllowUnknownValues let it through.
+	Validate func(cty.Value) tfdiags.Diagnostics
+
 	Description     string
 	DescriptionKind configschema.StringKind
 }
 
+// FunctionParamConstraints declares value constraints for a FunctionParam
+// that can be checked entirely in-process, without contacting the provider.
+// Only the fields that are relevant to the parameter's type need to be set;
+// the others are ignored.
+type FunctionParamConstraints struct {
+	// Min and Max bound a cty.Number argument, inclusive. Leave either as
+	// cty.NilVal to leave that side unbounded.
+	Min, Max cty.Value
+
+	// AllowedValues, if non-empty, restricts the argument to exactly one of
+	// these values.
+	AllowedValues []cty.Value
+
+	// Pattern, if non-empty, is a regular expression that a cty.String
+	// argument must match.
+	Pattern string
+
+	// NonEmpty requires a cty.String or collection-typed argument to have
+	// at least one character or element.
+	NonEmpty bool
+
+	// AllowedMarks lists the marks (for example marks.Sensitive, from
+	// internal/lang/marks, compared by equality) that this parameter
+	// tolerates on its argument. A mark not in this list causes Check to
+	// fail with a diagnostic instead of evaluating the other constraints
+	// against it. Marks that are in this list are stripped before Min, Max,
+	// AllowedValues, Pattern, and NonEmpty are evaluated, since none of
+	// those can be evaluated against a marked value. Leave this nil to
+	// reject any marked argument outright.
+	AllowedMarks []any
+
+	compilePatternOnce sync.Once
+	compiledPattern    *regexp.Regexp
+	compiledPatternErr error
+
+	// NOTE: Encoding these constraints into the plugin6 GetFunctions
+	// response, so a provider can advertise them without an instance being
+	// launched, requires a new field on that protobuf message, which lives
+	// outside this package and isn't part of this tree. See
+	// FunctionParamConstraintsProto for the wire shape this package exposes
+	// in the meantime; a caller with access to the plugin6 definitions can
+	// use it to fill in that field once it exists.
+}
+
+// markAllowed reports whether mark is listed in c.AllowedMarks.
+func (c *FunctionParamConstraints) markAllowed(mark any) bool {
+	for _, allowed := range c.AllowedMarks {
+		if allowed == mark {
+			return true
+		}
+	}
+	return false
+}
+
+// Check evaluates the constraints against v, which the caller has already
+// established is non-null and wholly known. name is the parameter name, used
+// only to produce a readable diagnostic.
+//
+// Check unmarks v itself, so it's safe to call directly against a marked
+// argument -- for example a sensitive value -- without panicking. This also
+// makes Check usable on its own, ahead of BuildFunction and without a
+// provider instance, by static analysis that wants to flag an obviously bad
+// literal argument.
+func (c *FunctionParamConstraints) Check(name string, v cty.Value) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	unmarked, valMarks := v.UnmarkDeep()
+	for mark := range valMarks {
+		if !c.markAllowed(mark) {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid function argument",
+				fmt.Sprintf("Argument %q is marked, which this function does not allow.", name),
+			))
+			return diags
+		}
+	}
+	v = unmarked
+
+	if c.Min != cty.NilVal && v.Type() == cty.Number {
+		if v.LessThan(c.Min).True() {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid function argument",
+				fmt.Sprintf("Argument %q must be greater than or equal to %s.", name, c.Min.AsBigFloat().String()),
+			))
+		}
+	}
+	if c.Max != cty.NilVal && v.Type() == cty.Number {
+		if v.GreaterThan(c.Max).True() {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid function argument",
+				fmt.Sprintf("Argument %q must be less than or equal to %s.", name, c.Max.AsBigFloat().String()),
+			))
+		}
+	}
+	if len(c.AllowedValues) > 0 {
+		var match bool
+		for _, allowed := range c.AllowedValues {
+			if v.RawEquals(allowed) {
+				match = true
+				break
+			}
+		}
+		if !match {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid function argument",
+				fmt.Sprintf("Argument %q does not match any of its allowed values.", name),
+			))
+		}
+	}
+	if c.Pattern != "" && v.Type() == cty.String {
+		c.compilePatternOnce.Do(func() {
+			c.compiledPattern, c.compiledPatternErr = regexp.Compile(c.Pattern)
+		})
+		if err := c.compiledPatternErr; err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid function argument",
+				fmt.Sprintf("Argument %q has an invalid constraint pattern: %s.", name, err),
+			))
+		} else if re := c.compiledPattern; !re.MatchString(v.AsString()) {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid function argument",
+				fmt.Sprintf("Argument %q does not match the required pattern %s.", name, c.Pattern),
+			))
+		}
+	}
+	if c.NonEmpty {
+		var empty bool
+		switch {
+		case v.Type() == cty.String:
+			empty = v.AsString() == ""
+		case v.Type().IsCollectionType() || v.Type().IsTupleType() || v.Type().IsObjectType():
+			empty = v.LengthInt() == 0
+		}
+		if empty {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid function argument",
+				fmt.Sprintf("Argument %q must not be empty.", name),
+			))
+		}
+	}
+
+	return diags
+}
+
 // BuildFunction takes a factory function which will return an unconfigured
 // instance of the provider this declaration belongs to and returns a
 // cty function that is ready to be called against that provider.
@@ -37,16 +213,70 @@ type FunctionParam struct {
 // registered this declaration, or the returned function will try to use an
 // invalid name, leading to errors or undefined behavior.
 //
+// providerAddr identifies the provider the factory builds instances of. It
+// is used to key the FunctionRuntime's instance pool and result cache, and
+// is otherwise not sent to the provider.
+//
 // If the given factory returns an instance of any provider other than the
 // one the declaration belongs to, or returns a _configured_ instance of
 // the provider rather than an unconfigured one, the behavior of the returned
 // function is undefined.
 //
-// Although not functionally required, callers should ideally pass a factory
-// function that either retrieves already-running plugins or memoizes the
-// plugins it returns so that many calls to functions in the same provider
-// will not incur a repeated startup cost.
-func (d *FunctionDecl) BuildFunction(name string, factory func() (Interface, error)) function.Function {
+// If runtime is non-nil, the returned function borrows provider instances
+// from the runtime's pool instead of launching and closing a new instance
+// on every call, and -- for declarations with Pure set -- consults and
+// populates the runtime's result cache before calling the provider at all.
+// Passing a nil runtime preserves the old behavior of calling factory and
+// then Close on every single invocation, which remains supported for
+// callers that cannot share a runtime across calls.
+//
+// Any non-error diagnostics the provider returns alongside the call are
+// silently discarded, because cty's function system only has room for a
+// plain Go error. Callers that want to see those diagnostics -- warnings,
+// deprecation notices, and the like -- should use
+// BuildFunctionWithDiagnostics instead.
+func (d *FunctionDecl) BuildFunction(name string, providerAddr addrs.Provider, factory func() (Interface, error), runtime *FunctionRuntime) function.Function {
+	return d.buildFunction(name, providerAddr, factory, runtime, nil)
+}
+
+// BuildFunctionWithDiagnostics is like BuildFunction except that any
+// non-error diagnostics returned by the provider alongside a successful or
+// failed call are passed to sink, rather than being discarded. This allows
+// a provider to emit warnings -- for example, a deprecation notice or a
+// partial-evaluation caveat -- that callers can surface the same way as any
+// other diagnostic.
+//
+// When the call fails because an argument violated its Constraints or
+// Validate hook, or because the provider's CallFunction response itself
+// carried an error diagnostic, the returned error is a *FunctionError, so
+// callers can use errors.As to recover the original diagnostic rather than
+// parsing the flattened error string. A failure to launch or close the
+// provider plugin, or a provider returning no result and no diagnostics,
+// has no diagnostic to carry and so is still a plain error, same as from
+// BuildFunction.
+func (d *FunctionDecl) BuildFunctionWithDiagnostics(name string, providerAddr addrs.Provider, factory func() (Interface, error), runtime *FunctionRuntime, sink func(tfdiags.Diagnostics)) function.Function {
+	return d.buildFunction(name, providerAddr, factory, runtime, sink)
+}
+
+// sinkWarnings passes the non-error diagnostics in diags to sink, if sink is
+// non-nil and there are any. Error-severity diagnostics are left for the
+// caller to report through the returned Go error instead.
+func sinkWarnings(sink func(tfdiags.Diagnostics), diags tfdiags.Diagnostics) {
+	if sink == nil {
+		return
+	}
+	var warnings tfdiags.Diagnostics
+	for _, diag := range diags {
+		if diag.Severity() != tfdiags.Error {
+			warnings = warnings.Append(diag)
+		}
+	}
+	if len(warnings) > 0 {
+		sink(warnings)
+	}
+}
+
+func (d *FunctionDecl) buildFunction(name string, providerAddr addrs.Provider, factory func() (Interface, error), runtime *FunctionRuntime, diagsSink func(tfdiags.Diagnostics)) function.Function {
 
 	var params []function.Parameter
 	var varParam *function.Parameter
@@ -83,29 +313,69 @@ func (d *FunctionDecl) BuildFunction(name string, factory func() (Interface, err
 				}
 			}
 
-			provider, err := factory()
-			if err != nil {
-				return cty.UnknownVal(retType), fmt.Errorf("failed to launch provider plugin: %s", err)
+			// Declarative constraints and the Validate hook let us reject
+			// an obviously-bad argument before paying for a plugin launch.
+			var argDiags tfdiags.Diagnostics
+			for i, arg := range args {
+				paramDecl := argParamDecl(i)
+				if arg.IsNull() || !arg.IsWhollyKnown() {
+					continue
+				}
+				argDiags = argDiags.Append(paramDecl.CheckArgument(arg))
+			}
+			sinkWarnings(diagsSink, argDiags)
+			if argDiags.HasErrors() {
+				return cty.UnknownVal(retType), NewFunctionError(argDiags)
+			}
+
+			var cacheKey string
+			var cacheable bool
+			if runtime != nil && d.Pure {
+				if key, ok := functionCacheKey(providerAddr, name, args); ok {
+					cacheKey = key
+					cacheable = true
+					if cached, ok := runtime.cache.get(cacheKey); ok {
+						return cached, nil
+					}
+				}
+			}
+
+			var provider Interface
+			if runtime != nil {
+				var release func()
+				var err error
+				provider, release, err = runtime.borrow(providerAddr, factory)
+				if err != nil {
+					return cty.UnknownVal(retType), fmt.Errorf("failed to launch provider plugin: %s", err)
+				}
+				defer release()
+			} else {
+				var err error
+				provider, err = factory()
+				if err != nil {
+					return cty.UnknownVal(retType), fmt.Errorf("failed to launch provider plugin: %s", err)
+				}
 			}
 
 			resp := provider.CallFunction(CallFunctionRequest{
 				FunctionName: name,
 				Arguments:    args,
 			})
-			// NOTE: We don't actually have any way to surface warnings
-			// from the function here, because functions just return normal
-			// Go errors rather than diagnostics.
+			sinkWarnings(diagsSink, resp.Diagnostics)
 			if resp.Diagnostics.HasErrors() {
-				return cty.UnknownVal(retType), resp.Diagnostics.Err()
+				return cty.UnknownVal(retType), NewFunctionError(resp.Diagnostics)
 			}
 
 			if resp.Result == cty.NilVal {
 				return cty.UnknownVal(retType), fmt.Errorf("provider returned no result and no errors")
 			}
 
-			err = provider.Close()
-			if err != nil {
-				return cty.UnknownVal(retType), fmt.Errorf("failed to terminate provider plugin: %s", err)
+			if runtime == nil {
+				if err := provider.Close(); err != nil {
+					return cty.UnknownVal(retType), fmt.Errorf("failed to terminate provider plugin: %s", err)
+				}
+			} else if cacheable {
+				runtime.cache.put(cacheKey, resp.Result)
 			}
 
 			return resp.Result, nil
@@ -113,6 +383,32 @@ func (d *FunctionDecl) BuildFunction(name string, factory func() (Interface, err
 	})
 }
 
+// CheckArgument runs Constraints and Validate against a single, non-null,
+// wholly-known argument value, exactly as BuildFunction's Impl does before
+// launching the provider. Exporting this lets callers that only have a
+// literal expression value in hand -- such as terraform validate's static
+// checks -- reject an obviously bad argument without ever building a
+// function.Function or contacting the provider.
+func (p *FunctionParam) CheckArgument(v cty.Value) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	if p.Constraints != nil {
+		diags = diags.Append(p.Constraints.Check(p.Name, v))
+	}
+	if p.Validate != nil {
+		diags = diags.Append(p.Validate(v))
+	}
+	return diags
+}
+
+// ctyParameter converts the declaration to the function.Parameter that
+// cty's function package works with.
+//
+// cty has no concept of a refined or constrained Type for a function
+// parameter -- function.Parameter carries only a plain cty.Type, and cty's
+// refinements apply to values (chiefly unknown results), not to the static
+// shape of a parameter. So Constraints has no representation here; use
+// CheckArgument directly against a literal value instead of expecting it to
+// show up on the function.Parameter returned by this method.
 func (p *FunctionParam) ctyParameter() function.Parameter {
 	return function.Parameter{
 		Name:      p.Name,
@@ -0,0 +1,149 @@
+package providers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+)
+
+func TestFunctionParamConstraints_Check(t *testing.T) {
+	tests := map[string]struct {
+		constraints *FunctionParamConstraints
+		value       cty.Value
+		wantErr     bool
+	}{
+		"min ok": {
+			&FunctionParamConstraints{Min: cty.NumberIntVal(0)},
+			cty.NumberIntVal(5),
+			false,
+		},
+		"min violated": {
+			&FunctionParamConstraints{Min: cty.NumberIntVal(0)},
+			cty.NumberIntVal(-1),
+			true,
+		},
+		"max violated": {
+			&FunctionParamConstraints{Max: cty.NumberIntVal(10)},
+			cty.NumberIntVal(11),
+			true,
+		},
+		"allowed values ok": {
+			&FunctionParamConstraints{AllowedValues: []cty.Value{cty.StringVal("a"), cty.StringVal("b")}},
+			cty.StringVal("b"),
+			false,
+		},
+		"allowed values violated": {
+			&FunctionParamConstraints{AllowedValues: []cty.Value{cty.StringVal("a"), cty.StringVal("b")}},
+			cty.StringVal("c"),
+			true,
+		},
+		"pattern ok": {
+			&FunctionParamConstraints{Pattern: `^\d+$`},
+			cty.StringVal("123"),
+			false,
+		},
+		"pattern violated": {
+			&FunctionParamConstraints{Pattern: `^\d+$`},
+			cty.StringVal("abc"),
+			true,
+		},
+		"non-empty string violated": {
+			&FunctionParamConstraints{NonEmpty: true},
+			cty.StringVal(""),
+			true,
+		},
+		"non-empty collection violated": {
+			&FunctionParamConstraints{NonEmpty: true},
+			cty.ListValEmpty(cty.String),
+			true,
+		},
+		"non-empty collection ok": {
+			&FunctionParamConstraints{NonEmpty: true},
+			cty.ListVal([]cty.Value{cty.StringVal("x")}),
+			false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			diags := test.constraints.Check("arg", test.value)
+			if got := diags.HasErrors(); got != test.wantErr {
+				t.Errorf("Check returned errors=%v, want %v (diags: %s)", got, test.wantErr, diags.Err())
+			}
+		})
+	}
+}
+
+func TestFunctionParamConstraints_CheckMarkedValue(t *testing.T) {
+	c := &FunctionParamConstraints{Min: cty.NumberIntVal(0)}
+
+	sensitive := cty.NumberIntVal(5).Mark("sensitive")
+
+	t.Run("disallowed mark", func(t *testing.T) {
+		diags := c.Check("arg", sensitive)
+		if !diags.HasErrors() {
+			t.Fatal("expected an error for a marked value with no AllowedMarks")
+		}
+	})
+
+	t.Run("allowed mark", func(t *testing.T) {
+		allowing := &FunctionParamConstraints{
+			Min:          cty.NumberIntVal(0),
+			AllowedMarks: []any{"sensitive"},
+		}
+		diags := allowing.Check("arg", sensitive)
+		if diags.HasErrors() {
+			t.Fatalf("did not expect an error for an allowed mark: %s", diags.Err())
+		}
+	})
+
+	t.Run("allowed mark still enforces other constraints", func(t *testing.T) {
+		allowing := &FunctionParamConstraints{
+			Min:          cty.NumberIntVal(0),
+			AllowedMarks: []any{"sensitive"},
+		}
+		diags := allowing.Check("arg", cty.NumberIntVal(-1).Mark("sensitive"))
+		if !diags.HasErrors() {
+			t.Fatal("expected the Min constraint to still be enforced against an unmarked copy of the value")
+		}
+	})
+}
+
+func TestBuildFunction_ConstraintRejectsWithoutLaunchingProvider(t *testing.T) {
+	var launched bool
+	factory := func() (Interface, error) {
+		launched = true
+		return nil, nil
+	}
+
+	decl := &FunctionDecl{
+		Parameters: []FunctionParam{
+			{
+				Name: "n",
+				Type: cty.Number,
+				Constraints: &FunctionParamConstraints{
+					Min: cty.NumberIntVal(0),
+				},
+			},
+		},
+		ReturnType: cty.Number,
+	}
+
+	fn := decl.BuildFunction("test", addrs.NewDefaultProvider("test"), factory, nil)
+
+	_, err := fn.Call([]cty.Value{cty.NumberIntVal(-1)})
+	if err == nil {
+		t.Fatal("expected an error for an out-of-range literal argument")
+	}
+	if launched {
+		t.Errorf("expected the provider not to be launched once the argument fails its constraints")
+	}
+
+	var funcErr *FunctionError
+	if !errors.As(err, &funcErr) {
+		t.Fatalf("expected the error to be recoverable as a *FunctionError, got %T: %s", err, err)
+	}
+}